@@ -15,6 +15,7 @@
 package stack
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -23,6 +24,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/cmd/pulumi/backend"
 	"github.com/pulumi/pulumi/pkg/v3/graph"
 	"github.com/pulumi/pulumi/pkg/v3/graph/dotconv"
+	"github.com/pulumi/pulumi/pkg/v3/graph/encoding"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
 	pkgWorkspace "github.com/pulumi/pulumi/pkg/v3/workspace"
@@ -52,21 +54,72 @@ type graphCommandOptions struct {
 	// A DOT fragment that will be inserted at the top of the digraph element. This
 	// can be used for styling the graph elements, setting graph properties etc.")
 	dotFragment string
+
+	// The output format: one of "dot", "json", "mermaid", "graphml", or "svg". Defaults to "dot".
+	format string
+
+	// URN globs identifying the resources to focus the graph around. When set, the graph is
+	// restricted to the transitive dependency and parent closure of the matching resources.
+	focus []string
+
+	// Bounds the --focus closure to this many edges from a focused resource. A negative value
+	// (the default) means the closure is unbounded.
+	depth int
+
+	// Type globs identifying the only resource types to include in the graph.
+	filterType []string
+
+	// Type globs identifying resource types to exclude from the graph.
+	excludeType []string
+
+	// Whether or not to collapse each resource into its provider, to get a birds-eye view of a
+	// stack that uses many instances of a handful of providers.
+	onlyProviders bool
+
+	// Whether or not to run structural validation instead of writing a graph file: looks for
+	// cycles, dangling references, orphaned providers, and duplicate URNs.
+	validate bool
 }
 
 func newStackGraphCmd() *cobra.Command {
 	var cmdOpts graphCommandOptions
 
 	cmd := &cobra.Command{
-		Use:   "graph [filename]",
-		Args:  cmdutil.ExactArgs(1),
+		Use: "graph [filename]",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmdOpts.validate {
+				return cobra.ExactArgs(0)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Short: "Export a stack's dependency graph to a file",
 		Long: "Export a stack's dependency graph to a file.\n" +
 			"\n" +
 			"This command can be used to view the dependency graph that a Pulumi program\n" +
-			"emitted when it was run. This graph is output in the DOT format. This command operates\n" +
-			"on your stack's most recent deployment.",
+			"emitted when it was run. This graph is output in the DOT format by default; pass\n" +
+			"`--format` to export it as JSON, Mermaid, GraphML, or SVG instead. This command\n" +
+			"operates on your stack's most recent deployment.\n" +
+			"\n" +
+			"Large stacks can produce unreadable graphs. Pass `--focus <urn-glob>` to restrict the\n" +
+			"output to the transitive dependency and parent closure around matching resources,\n" +
+			"optionally bounded with `--depth`; `--filter <type-glob>` and `--exclude-type\n" +
+			"<type-glob>` to prune by resource type; and `--only-providers` to collapse each\n" +
+			"resource into its provider for a birds-eye view of a stack that uses many instances of\n" +
+			"a handful of providers.\n" +
+			"\n" +
+			"Pass `--validate` to run structural checks against the graph instead of writing it to a\n" +
+			"file: this looks for cycles, dangling references, orphaned providers, and duplicate\n" +
+			"URNs, printing a machine-readable report and exiting non-zero if it finds anything.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var format encoding.Format
+			if !cmdOpts.validate {
+				var err error
+				format, err = encoding.ParseFormat(cmdOpts.format)
+				if err != nil {
+					return err
+				}
+			}
+
 			ctx := cmd.Context()
 			ws := pkgWorkspace.Instance
 			opts := display.Options{
@@ -95,14 +148,50 @@ func newStackGraphCmd() *cobra.Command {
 				return fmt.Errorf("unable to find snapshot for stack %q", cmdOpts.stackName)
 			}
 
+			if cmdOpts.validate {
+				report := ValidateSnapshot(snap)
+
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+
+				if report.HasProblems() {
+					return fmt.Errorf("stack graph validation found %d problem(s); see report above", report.ProblemCount())
+				}
+				return nil
+			}
+
 			dg := makeDependencyGraph(snap, &cmdOpts)
 
+			if len(cmdOpts.filterType) > 0 || len(cmdOpts.excludeType) > 0 {
+				dg = dg.FilterByType(cmdOpts.filterType, cmdOpts.excludeType)
+			}
+
+			if len(cmdOpts.focus) > 0 {
+				seeds, err := dg.urnsMatching(cmdOpts.focus)
+				if err != nil {
+					return err
+				}
+				dg = dg.Subgraph(seeds, cmdOpts.depth)
+			}
+
+			if cmdOpts.onlyProviders {
+				dg = dg.CollapseToProviders()
+			}
+
 			file, err := os.Create(args[0])
 			if err != nil {
 				return err
 			}
 
-			if err := dotconv.Print(dg, file, cmdOpts.dotFragment); err != nil {
+			if format == encoding.DOT {
+				err = dotconv.Print(dg, file, cmdOpts.dotFragment)
+			} else {
+				err = encoding.Encode(format, dg, file)
+			}
+			if err != nil {
 				_ = file.Close()
 				return err
 			}
@@ -127,6 +216,24 @@ func newStackGraphCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&cmdOpts.dotFragment, "dot-fragment", "",
 		"An optional DOT fragment that will be inserted at the top of the digraph element. "+
 			"This can be used for styling the graph elements, setting graph properties etc.")
+	cmd.PersistentFlags().StringVar(&cmdOpts.format, "format", "dot",
+		"The output format to use: dot, json, mermaid, graphml, or svg")
+	cmd.PersistentFlags().StringArrayVar(&cmdOpts.focus, "focus", nil,
+		"Restrict the graph to the transitive dependency and parent closure around resources "+
+			"whose URN matches this glob (may be repeated)")
+	cmd.PersistentFlags().IntVar(&cmdOpts.depth, "depth", -1,
+		"Bounds the --focus closure to this many edges from a focused resource; "+
+			"a negative value means the closure is unbounded")
+	cmd.PersistentFlags().StringArrayVar(&cmdOpts.filterType, "filter", nil,
+		"Only include resources whose type matches this glob (may be repeated)")
+	cmd.PersistentFlags().StringArrayVar(&cmdOpts.excludeType, "exclude-type", nil,
+		"Exclude resources whose type matches this glob (may be repeated)")
+	cmd.PersistentFlags().BoolVar(&cmdOpts.onlyProviders, "only-providers", false,
+		"Collapses each resource into its provider, to get a birds-eye view of a stack "+
+			"that uses many instances of a handful of providers")
+	cmd.PersistentFlags().BoolVar(&cmdOpts.validate, "validate", false,
+		"Validates the stack's dependency graph for cycles, dangling references, orphaned "+
+			"providers, and duplicate URNs instead of writing a graph file; exits non-zero if it finds any")
 	return cmd
 }
 
@@ -164,6 +271,11 @@ func (edge *dependencyEdge) Color() string {
 	return edge.color
 }
 
+// Kind identifies this edge as a dependency edge to the graph/encoding package.
+func (edge *dependencyEdge) Kind() string {
+	return encoding.KindDependency
+}
+
 // parentEdges represent edges in the parent-child graph, which
 // exists alongside the dependency graph. An edge exists from node
 // A to node B if node B is considered to be a parent of node A.
@@ -194,6 +306,11 @@ func (edge *parentEdge) Color() string {
 	return edge.color
 }
 
+// Kind identifies this edge as a parent edge to the graph/encoding package.
+func (edge *parentEdge) Kind() string {
+	return encoding.KindParent
+}
+
 // A dependencyVertex contains a reference to the graph to which it belongs
 // and to the resource state that it represents. Incoming and outgoing edges
 // are calculated on-demand using the combination of the graph and the state.
@@ -231,6 +348,27 @@ func (vertex *dependencyVertex) Outs() []graph.Edge {
 // the graph. It is constructed directly from a snapshot.
 type dependencyGraph struct {
 	vertices map[resource.URN]*dependencyVertex
+
+	// The construction options the graph was built with, retained so that derived graphs
+	// (Subgraph, FilterByType, CollapseToProviders) can be built with the same edge colors
+	// and node-label settings.
+	shortNodeName         bool
+	ignoreDependencyEdges bool
+	ignoreParentEdges     bool
+	dependencyEdgeColor   string
+	parentEdgeColor       string
+}
+
+// options reconstructs the graphCommandOptions that dg was built with, for passing to
+// buildGraph when deriving a new graph from this one.
+func (dg *dependencyGraph) options() *graphCommandOptions {
+	return &graphCommandOptions{
+		shortNodeName:         dg.shortNodeName,
+		ignoreDependencyEdges: dg.ignoreDependencyEdges,
+		ignoreParentEdges:     dg.ignoreParentEdges,
+		dependencyEdgeColor:   dg.dependencyEdgeColor,
+		parentEdgeColor:       dg.parentEdgeColor,
+	}
 }
 
 // Roots are edges that point to the root set of our graph. In our case,
@@ -252,18 +390,32 @@ func (dg *dependencyGraph) Roots() []graph.Edge {
 // Makes a dependency graph from a deployment snapshot, allocating a vertex
 // for every resource in the graph.
 func makeDependencyGraph(snapshot *deploy.Snapshot, opts *graphCommandOptions) *dependencyGraph {
+	return buildGraph(snapshot.Resources, opts)
+}
+
+// buildGraph assembles a dependencyGraph over exactly the given resources, wiring up
+// dependency and parent edges between them. An edge that would reference a resource outside
+// the given set is dropped rather than causing a nil-pointer vertex, which is what lets
+// Subgraph, FilterByType, and CollapseToProviders build a graph restricted to some subset of
+// resources without producing dangling edges.
+func buildGraph(resources []*resource.State, opts *graphCommandOptions) *dependencyGraph {
 	dg := &dependencyGraph{
-		vertices: make(map[resource.URN]*dependencyVertex),
+		vertices:              make(map[resource.URN]*dependencyVertex),
+		shortNodeName:         opts.shortNodeName,
+		ignoreDependencyEdges: opts.ignoreDependencyEdges,
+		ignoreParentEdges:     opts.ignoreParentEdges,
+		dependencyEdgeColor:   opts.dependencyEdgeColor,
+		parentEdgeColor:       opts.parentEdgeColor,
 	}
 
-	for _, resource := range snapshot.Resources {
+	for _, res := range resources {
 		vertex := &dependencyVertex{
 			graph:        dg,
-			resource:     resource,
+			resource:     res,
 			useShortName: opts.shortNodeName,
 		}
 
-		dg.vertices[resource.URN] = vertex
+		dg.vertices[res.URN] = vertex
 	}
 
 	for _, vertex := range dg.vertices {
@@ -280,7 +432,10 @@ func makeDependencyGraph(snapshot *deploy.Snapshot, opts *graphCommandOptions) *
 			// Incoming edges are directly stored within the checkpoint file; they represent
 			// resources on which this vertex immediately depends upon.
 			for _, dep := range vertex.resource.Dependencies {
-				vertexWeDependOn := vertex.graph.vertices[dep]
+				vertexWeDependOn, ok := vertex.graph.vertices[dep]
+				if !ok {
+					continue
+				}
 				edge := &dependencyEdge{to: vertex, from: vertexWeDependOn, labels: depBlame[dep], color: opts.dependencyEdgeColor}
 				vertex.incomingEdges = append(vertex.incomingEdges, edge)
 				vertexWeDependOn.outgoingEdges = append(vertexWeDependOn.outgoingEdges, edge)
@@ -292,12 +447,13 @@ func makeDependencyGraph(snapshot *deploy.Snapshot, opts *graphCommandOptions) *
 		// edges.
 		if !opts.ignoreParentEdges {
 			if parent := vertex.resource.Parent; parent != resource.URN("") {
-				parentVertex := dg.vertices[parent]
-				vertex.outgoingEdges = append(vertex.outgoingEdges, &parentEdge{
-					to:    parentVertex,
-					from:  vertex,
-					color: opts.parentEdgeColor,
-				})
+				if parentVertex, ok := dg.vertices[parent]; ok {
+					vertex.outgoingEdges = append(vertex.outgoingEdges, &parentEdge{
+						to:    parentVertex,
+						from:  vertex,
+						color: opts.parentEdgeColor,
+					})
+				}
 			}
 		}
 	}
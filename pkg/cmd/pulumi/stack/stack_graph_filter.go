@@ -0,0 +1,299 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Subgraph returns a new dependencyGraph containing only the resources within depth edges of
+// the given seed URNs, following both dependency and parent edges in either direction. A
+// negative depth walks the full transitive closure. Seeds that aren't present in dg are
+// ignored.
+func (dg *dependencyGraph) Subgraph(seeds []resource.URN, depth int) *dependencyGraph {
+	type frontierEntry struct {
+		urn   resource.URN
+		depth int
+	}
+
+	// Parent edges are only recorded on the child (see buildGraph), so collect each vertex's
+	// children up front in order to also walk parent edges from parent to child.
+	children := make(map[resource.URN][]*dependencyVertex)
+	for _, vertex := range dg.vertices {
+		for _, e := range vertex.outgoingEdges {
+			if pe, ok := e.(*parentEdge); ok {
+				children[pe.to.resource.URN] = append(children[pe.to.resource.URN], vertex)
+			}
+		}
+	}
+
+	keep := make(map[resource.URN]bool)
+	var frontier []frontierEntry
+	for _, seed := range seeds {
+		if _, ok := dg.vertices[seed]; ok && !keep[seed] {
+			keep[seed] = true
+			frontier = append(frontier, frontierEntry{urn: seed, depth: 0})
+		}
+	}
+
+	for len(frontier) > 0 {
+		entry := frontier[0]
+		frontier = frontier[1:]
+		if depth >= 0 && entry.depth >= depth {
+			continue
+		}
+
+		vertex := dg.vertices[entry.urn]
+		visit := func(neighbor *dependencyVertex) {
+			if neighbor == nil || keep[neighbor.resource.URN] {
+				return
+			}
+			keep[neighbor.resource.URN] = true
+			frontier = append(frontier, frontierEntry{urn: neighbor.resource.URN, depth: entry.depth + 1})
+		}
+
+		for _, e := range vertex.incomingEdges {
+			if from, ok := e.From().(*dependencyVertex); ok {
+				visit(from)
+			}
+		}
+		for _, e := range vertex.outgoingEdges {
+			if to, ok := e.To().(*dependencyVertex); ok {
+				visit(to)
+			}
+		}
+		for _, child := range children[entry.urn] {
+			visit(child)
+		}
+	}
+
+	resources := make([]*resource.State, 0, len(keep))
+	for urn, vertex := range dg.vertices {
+		if keep[urn] {
+			resources = append(resources, vertex.resource)
+		}
+	}
+
+	return buildGraph(resources, dg.options())
+}
+
+// FilterByType returns a new dependencyGraph containing only the resources whose type matches
+// at least one of the include globs (every type, if include is empty) and none of the exclude
+// globs.
+func (dg *dependencyGraph) FilterByType(include, exclude []string) *dependencyGraph {
+	includeRe := compileGlobs(include)
+	excludeRe := compileGlobs(exclude)
+
+	resources := make([]*resource.State, 0, len(dg.vertices))
+	for _, vertex := range dg.vertices {
+		typ := string(vertex.resource.Type)
+		if len(includeRe) > 0 && !matchesAny(includeRe, typ) {
+			continue
+		}
+		if matchesAny(excludeRe, typ) {
+			continue
+		}
+		resources = append(resources, vertex.resource)
+	}
+
+	return buildGraph(resources, dg.options())
+}
+
+// CollapseToProviders returns a new dependencyGraph where every resource managed by a provider
+// is replaced by a single node representing that provider, with dependency and parent edges
+// re-pointed accordingly (respecting dg.ignoreDependencyEdges / dg.ignoreParentEdges, same as
+// buildGraph). Resources with no provider -- including providers themselves -- are kept as-is.
+// This gives a birds-eye view of a stack that uses many instances of a handful of providers.
+func (dg *dependencyGraph) CollapseToProviders() *dependencyGraph {
+	// representative maps a resource's URN to the URN of the node that should stand in for it
+	// in the collapsed graph: its provider, or itself if it has none.
+	representative := make(map[resource.URN]resource.URN, len(dg.vertices))
+	kept := make(map[resource.URN]*resource.State)
+
+	for urn, vertex := range dg.vertices {
+		provider := providerURN(vertex.resource.Provider)
+		if provider == "" {
+			representative[urn] = urn
+			kept[urn] = vertex.resource
+			continue
+		}
+
+		representative[urn] = provider
+		if _, ok := kept[provider]; ok {
+			continue
+		}
+		if providerVertex, ok := dg.vertices[provider]; ok {
+			kept[provider] = providerVertex.resource
+		} else {
+			// The provider resource itself isn't part of this graph (e.g. it was pruned by an
+			// earlier --filter); synthesize a stand-in so its dependents still have a node to
+			// point at.
+			kept[provider] = &resource.State{URN: provider, Type: "pulumi:providers"}
+		}
+	}
+
+	resources := make([]*resource.State, 0, len(kept))
+	for _, res := range kept {
+		resources = append(resources, res)
+	}
+	collapsed := buildGraph(resources, dg.options())
+
+	// buildGraph only knows about edges between the resources we passed it, i.e. between
+	// providers and resources with no provider. Re-derive the edges between representatives from
+	// the original resource dependencies and parents, skipping those buildGraph already added and
+	// deduplicating so that N resources sharing a provider pair don't produce N redundant edges.
+	seenDependencyEdges := make(map[[2]resource.URN]bool)
+	seenParentEdges := make(map[[2]resource.URN]bool)
+	for urn, vertex := range collapsed.vertices {
+		for _, e := range vertex.outgoingEdges {
+			switch e := e.(type) {
+			case *dependencyEdge:
+				seenDependencyEdges[[2]resource.URN{urn, e.to.resource.URN}] = true
+			case *parentEdge:
+				seenParentEdges[[2]resource.URN{urn, e.to.resource.URN}] = true
+			}
+		}
+	}
+	for urn, vertex := range dg.vertices {
+		to := representative[urn]
+		toVertex := collapsed.vertices[to]
+		if toVertex == nil {
+			continue
+		}
+
+		if !dg.ignoreDependencyEdges {
+			for _, dep := range vertex.resource.Dependencies {
+				from, ok := representative[dep]
+				if !ok || from == to {
+					continue
+				}
+				fromVertex := collapsed.vertices[from]
+				if fromVertex == nil {
+					continue
+				}
+
+				key := [2]resource.URN{from, to}
+				if seenDependencyEdges[key] {
+					continue
+				}
+				seenDependencyEdges[key] = true
+
+				edge := &dependencyEdge{to: toVertex, from: fromVertex, color: dg.dependencyEdgeColor}
+				toVertex.incomingEdges = append(toVertex.incomingEdges, edge)
+				fromVertex.outgoingEdges = append(fromVertex.outgoingEdges, edge)
+			}
+		}
+
+		if !dg.ignoreParentEdges {
+			if parent := vertex.resource.Parent; parent != resource.URN("") {
+				// Parent edges point from child to parent (see buildGraph), the reverse of the
+				// "to"/"from" roles used for dependency edges above: the child is this vertex's
+				// own representative ("to" above), and the parent is the other end ("from").
+				parentRep, ok := representative[parent]
+				if !ok || parentRep == to {
+					continue
+				}
+				parentVertex := collapsed.vertices[parentRep]
+				if parentVertex == nil {
+					continue
+				}
+
+				key := [2]resource.URN{to, parentRep}
+				if seenParentEdges[key] {
+					continue
+				}
+				seenParentEdges[key] = true
+
+				edge := &parentEdge{to: parentVertex, from: toVertex, color: dg.parentEdgeColor}
+				parentVertex.incomingEdges = append(parentVertex.incomingEdges, edge)
+				toVertex.outgoingEdges = append(toVertex.outgoingEdges, edge)
+			}
+		}
+	}
+
+	return collapsed
+}
+
+// providerURN extracts the URN of the provider resource that a "provider reference" (as stored
+// in resource.State.Provider) identifies, returning "" if ref is empty or malformed.
+func providerURN(ref string) resource.URN {
+	if ref == "" {
+		return ""
+	}
+	urn, _, err := providers.ParseReference(ref)
+	if err != nil {
+		return ""
+	}
+	return urn
+}
+
+// urnsMatching returns the URNs of dg's vertices that match at least one of the given globs,
+// erroring if none do.
+func (dg *dependencyGraph) urnsMatching(globs []string) ([]resource.URN, error) {
+	res := compileGlobs(globs)
+
+	var matches []resource.URN
+	for urn := range dg.vertices {
+		if matchesAny(res, string(urn)) {
+			matches = append(matches, urn)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("--focus matched no resources (pattern(s): %s)", strings.Join(globs, ", "))
+	}
+	return matches, nil
+}
+
+// compileGlobs compiles each of the given shell-style globs ('*' and '?' wildcards) into a
+// regexp anchored to match the whole string.
+func compileGlobs(globs []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(globs))
+	for i, glob := range globs {
+		res[i] = globToRegexp(glob)
+	}
+	return res
+}
+
+func globToRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+// A small fixture: a chain of three custom resources (a <- b <- c, where b depends on a and
+// c depends on b), plus an unrelated fourth resource d.
+func fixtureSnapshot() *deploy.Snapshot {
+	a := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::a", Type: "a:b:C"}
+	b := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::b", Type: "a:b:C", Dependencies: []resource.URN{a.URN}}
+	c := &resource.State{URN: "urn:pulumi:dev::app::a:b:D::c", Type: "a:b:D", Dependencies: []resource.URN{b.URN}}
+	d := &resource.State{URN: "urn:pulumi:dev::app::a:b:D::d", Type: "a:b:D"}
+	return &deploy.Snapshot{Resources: []*resource.State{a, b, c, d}}
+}
+
+func urnsOf(dg *dependencyGraph) []string {
+	var urns []string
+	for urn := range dg.vertices {
+		urns = append(urns, string(urn))
+	}
+	sort.Strings(urns)
+	return urns
+}
+
+func TestSubgraphUnboundedDepth(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshot(), &graphCommandOptions{})
+	seeds, err := dg.urnsMatching([]string{"*::c"})
+	assert.NoError(t, err)
+
+	sub := dg.Subgraph(seeds, -1)
+	assert.ElementsMatch(t, []string{
+		"urn:pulumi:dev::app::a:b:C::a",
+		"urn:pulumi:dev::app::a:b:C::b",
+		"urn:pulumi:dev::app::a:b:D::c",
+	}, urnsOf(sub))
+}
+
+func TestSubgraphBoundedDepth(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshot(), &graphCommandOptions{})
+	seeds, err := dg.urnsMatching([]string{"*::c"})
+	assert.NoError(t, err)
+
+	sub := dg.Subgraph(seeds, 1)
+	assert.ElementsMatch(t, []string{
+		"urn:pulumi:dev::app::a:b:C::b",
+		"urn:pulumi:dev::app::a:b:D::c",
+	}, urnsOf(sub))
+}
+
+func TestFilterByType(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshot(), &graphCommandOptions{})
+
+	included := dg.FilterByType([]string{"a:b:D"}, nil)
+	assert.ElementsMatch(t, []string{
+		"urn:pulumi:dev::app::a:b:D::c",
+		"urn:pulumi:dev::app::a:b:D::d",
+	}, urnsOf(included))
+
+	excluded := dg.FilterByType(nil, []string{"a:b:D"})
+	assert.ElementsMatch(t, []string{
+		"urn:pulumi:dev::app::a:b:C::a",
+		"urn:pulumi:dev::app::a:b:C::b",
+	}, urnsOf(excluded))
+}
+
+func TestUrnsMatchingNoMatches(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshot(), &graphCommandOptions{})
+	_, err := dg.urnsMatching([]string{"no-such-*"})
+	assert.Error(t, err)
+}
+
+// fixtureSnapshotWithProviders builds two providers, p1 and p2, each managing one resource (r1
+// and r2 respectively). r2 depends on r1 and is parented to r1, so collapsing to providers should
+// produce a single dependency edge from p1 to p2 and a single parent edge from p2 to p1 -- edges
+// that cross the provider boundary, not the resource-level ones they were derived from.
+func fixtureSnapshotWithProviders() *deploy.Snapshot {
+	p1 := &resource.State{URN: "urn:pulumi:dev::app::pulumi:providers:aws::p1", Type: "pulumi:providers:aws"}
+	p2 := &resource.State{URN: "urn:pulumi:dev::app::pulumi:providers:aws::p2", Type: "pulumi:providers:aws"}
+
+	r1 := &resource.State{
+		URN:      "urn:pulumi:dev::app::aws:s3:Bucket::r1",
+		Type:     "aws:s3:Bucket",
+		Provider: string(p1.URN) + "::00000000-0000-0000-0000-000000000001",
+	}
+	r2 := &resource.State{
+		URN:          "urn:pulumi:dev::app::aws:s3:Bucket::r2",
+		Type:         "aws:s3:Bucket",
+		Provider:     string(p2.URN) + "::00000000-0000-0000-0000-000000000002",
+		Parent:       r1.URN,
+		Dependencies: []resource.URN{r1.URN},
+	}
+
+	return &deploy.Snapshot{Resources: []*resource.State{p1, p2, r1, r2}}
+}
+
+func TestCollapseToProviders(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshotWithProviders(), &graphCommandOptions{})
+	collapsed := dg.CollapseToProviders()
+
+	assert.ElementsMatch(t, []string{
+		"urn:pulumi:dev::app::pulumi:providers:aws::p1",
+		"urn:pulumi:dev::app::pulumi:providers:aws::p2",
+	}, urnsOf(collapsed))
+
+	p1Vertex := collapsed.vertices["urn:pulumi:dev::app::pulumi:providers:aws::p1"]
+	p2Vertex := collapsed.vertices["urn:pulumi:dev::app::pulumi:providers:aws::p2"]
+	// As in buildGraph, the dependency edge runs from the dependency's side (p1) to the
+	// dependent's (p2), and the parent edge runs from the child's side (p2) to the parent's (p1).
+	assert.Len(t, p1Vertex.outgoingEdges, 1, "expected one dependency edge from p1 to p2")
+	assert.Len(t, p2Vertex.incomingEdges, 1)
+	assert.Len(t, p2Vertex.outgoingEdges, 1, "expected one parent edge from p2 to p1")
+	assert.Len(t, p1Vertex.incomingEdges, 1)
+
+	var sawDependency, sawParent bool
+	edges := append([]graph.Edge{}, p1Vertex.outgoingEdges...)
+	for _, e := range append(edges, p2Vertex.outgoingEdges...) {
+		switch edge := e.(type) {
+		case *dependencyEdge:
+			sawDependency = true
+			assert.Same(t, p2Vertex, edge.to)
+			assert.Same(t, p1Vertex, edge.from)
+		case *parentEdge:
+			sawParent = true
+			assert.Same(t, p1Vertex, edge.to)
+			assert.Same(t, p2Vertex, edge.from)
+		}
+	}
+	assert.True(t, sawDependency, "missing collapsed dependency edge")
+	assert.True(t, sawParent, "missing collapsed parent edge")
+}
+
+func TestCollapseToProvidersIgnoresEdgesPerOptions(t *testing.T) {
+	t.Parallel()
+
+	dg := makeDependencyGraph(fixtureSnapshotWithProviders(), &graphCommandOptions{
+		ignoreDependencyEdges: true,
+		ignoreParentEdges:     true,
+	})
+	collapsed := dg.CollapseToProviders()
+
+	p2Vertex := collapsed.vertices["urn:pulumi:dev::app::pulumi:providers:aws::p2"]
+	assert.Empty(t, p2Vertex.outgoingEdges)
+}
@@ -0,0 +1,172 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"sort"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// ValidationReport is the machine-readable result of structurally validating a stack's
+// assembled dependency graph. Fields are omitted from JSON output when there's nothing to
+// report, so a clean stack serializes to `{}`.
+type ValidationReport struct {
+	Cycles             []CycleIssue             `json:"cycles,omitempty"`
+	DanglingReferences []DanglingReferenceIssue `json:"danglingReferences,omitempty"`
+	OrphanedProviders  []OrphanedProviderIssue  `json:"orphanedProviders,omitempty"`
+	DuplicateURNs      []DuplicateURNIssue      `json:"duplicateUrns,omitempty"`
+}
+
+// CycleIssue reports a cycle found among dependency or parent edges, as the sorted URNs of the
+// resources participating in it.
+type CycleIssue struct {
+	URNs []resource.URN `json:"urns"`
+}
+
+// DanglingReferenceIssue reports a resource whose reference -- a dependency, parent,
+// deleted-with, or property dependency -- points to a URN that isn't present in the snapshot.
+type DanglingReferenceIssue struct {
+	Resource resource.URN `json:"resource"`
+	Field    string       `json:"field"`
+	Target   resource.URN `json:"target"`
+}
+
+// OrphanedProviderIssue reports a resource whose provider reference points to a provider that
+// isn't present in the snapshot.
+type OrphanedProviderIssue struct {
+	Resource resource.URN `json:"resource"`
+	Provider resource.URN `json:"provider"`
+}
+
+// DuplicateURNIssue reports a URN that appears more than once in the snapshot.
+type DuplicateURNIssue struct {
+	URN   resource.URN `json:"urn"`
+	Count int          `json:"count"`
+}
+
+// HasProblems reports whether the validation found anything worth a non-zero exit code.
+func (r *ValidationReport) HasProblems() bool {
+	return len(r.Cycles) > 0 || len(r.DanglingReferences) > 0 ||
+		len(r.OrphanedProviders) > 0 || len(r.DuplicateURNs) > 0
+}
+
+// ProblemCount returns the total number of issues found, across all categories.
+func (r *ValidationReport) ProblemCount() int {
+	return len(r.Cycles) + len(r.DanglingReferences) + len(r.OrphanedProviders) + len(r.DuplicateURNs)
+}
+
+// ValidateSnapshot runs structural checks against a deployment snapshot: cycles in the
+// dependency and parent graphs (via Tarjan's SCC), dangling references, orphaned providers, and
+// duplicate URNs.
+func ValidateSnapshot(snapshot *deploy.Snapshot) *ValidationReport {
+	report := &ValidationReport{}
+
+	report.DuplicateURNs = findDuplicateURNs(snapshot.Resources)
+
+	dg := buildGraph(snapshot.Resources, &graphCommandOptions{})
+
+	for _, res := range snapshot.Resources {
+		report.DanglingReferences = append(report.DanglingReferences, danglingReferences(dg, res)...)
+
+		if provider := providerURN(res.Provider); provider != "" {
+			if _, ok := dg.vertices[provider]; !ok {
+				report.OrphanedProviders = append(report.OrphanedProviders,
+					OrphanedProviderIssue{Resource: res.URN, Provider: provider})
+			}
+		}
+	}
+
+	// Dependency edges point from a dependency to its dependent while parent edges point from a
+	// child to its parent, so each kind of edge is checked for cycles in a graph of its own: a
+	// resource that depends on its own parent is not a cycle.
+	for _, edgeOpts := range []*graphCommandOptions{{ignoreParentEdges: true}, {ignoreDependencyEdges: true}} {
+		for _, scc := range graph.StronglyConnectedComponents(buildGraph(snapshot.Resources, edgeOpts)) {
+			if !scc.HasCycle() {
+				continue
+			}
+
+			urns := make([]resource.URN, 0, len(scc))
+			for _, v := range scc {
+				if dv, ok := v.(*dependencyVertex); ok {
+					urns = append(urns, dv.resource.URN)
+				}
+			}
+			sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+			report.Cycles = append(report.Cycles, CycleIssue{URNs: urns})
+		}
+	}
+
+	sort.Slice(report.DanglingReferences, func(i, j int) bool {
+		a, b := report.DanglingReferences[i], report.DanglingReferences[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Field < b.Field
+	})
+	sort.Slice(report.OrphanedProviders, func(i, j int) bool {
+		return report.OrphanedProviders[i].Resource < report.OrphanedProviders[j].Resource
+	})
+	sort.Slice(report.Cycles, func(i, j int) bool {
+		return len(report.Cycles[i].URNs) > 0 && len(report.Cycles[j].URNs) > 0 &&
+			report.Cycles[i].URNs[0] < report.Cycles[j].URNs[0]
+	})
+
+	return report
+}
+
+func findDuplicateURNs(resources []*resource.State) []DuplicateURNIssue {
+	counts := make(map[resource.URN]int, len(resources))
+	for _, res := range resources {
+		counts[res.URN]++
+	}
+
+	var issues []DuplicateURNIssue
+	for urn, count := range counts {
+		if count > 1 {
+			issues = append(issues, DuplicateURNIssue{URN: urn, Count: count})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].URN < issues[j].URN })
+	return issues
+}
+
+func danglingReferences(dg *dependencyGraph, res *resource.State) []DanglingReferenceIssue {
+	var issues []DanglingReferenceIssue
+
+	check := func(field string, target resource.URN) {
+		if target == "" {
+			return
+		}
+		if _, ok := dg.vertices[target]; !ok {
+			issues = append(issues, DanglingReferenceIssue{Resource: res.URN, Field: field, Target: target})
+		}
+	}
+
+	check("parent", res.Parent)
+	check("deletedWith", res.DeletedWith)
+	for _, dep := range res.Dependencies {
+		check("dependencies", dep)
+	}
+	for _, deps := range res.PropertyDependencies {
+		for _, dep := range deps {
+			check("propertyDependencies", dep)
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,87 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSnapshotClean(t *testing.T) {
+	t.Parallel()
+
+	a := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::a"}
+	b := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::b", Dependencies: []resource.URN{a.URN}}
+
+	report := ValidateSnapshot(&deploy.Snapshot{Resources: []*resource.State{a, b}})
+	assert.False(t, report.HasProblems())
+	assert.Equal(t, 0, report.ProblemCount())
+}
+
+func TestValidateSnapshotDetectsCycle(t *testing.T) {
+	t.Parallel()
+
+	a := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::a"}
+	b := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::b"}
+	a.Dependencies = []resource.URN{b.URN}
+	b.Dependencies = []resource.URN{a.URN}
+
+	report := ValidateSnapshot(&deploy.Snapshot{Resources: []*resource.State{a, b}})
+	assert.True(t, report.HasProblems())
+	assert.Len(t, report.Cycles, 1)
+	assert.ElementsMatch(t, []resource.URN{a.URN, b.URN}, report.Cycles[0].URNs)
+}
+
+func TestValidateSnapshotDetectsDanglingReference(t *testing.T) {
+	t.Parallel()
+
+	a := &resource.State{
+		URN:          "urn:pulumi:dev::app::a:b:C::a",
+		Dependencies: []resource.URN{"urn:pulumi:dev::app::a:b:C::missing"},
+	}
+
+	report := ValidateSnapshot(&deploy.Snapshot{Resources: []*resource.State{a}})
+	assert.True(t, report.HasProblems())
+	assert.Len(t, report.DanglingReferences, 1)
+	assert.Equal(t, "dependencies", report.DanglingReferences[0].Field)
+}
+
+func TestValidateSnapshotDetectsDuplicateURN(t *testing.T) {
+	t.Parallel()
+
+	a1 := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::a"}
+	a2 := &resource.State{URN: "urn:pulumi:dev::app::a:b:C::a"}
+
+	report := ValidateSnapshot(&deploy.Snapshot{Resources: []*resource.State{a1, a2}})
+	assert.True(t, report.HasProblems())
+	assert.Len(t, report.DuplicateURNs, 1)
+	assert.Equal(t, 2, report.DuplicateURNs[0].Count)
+}
+
+func TestValidateSnapshotDetectsOrphanedProvider(t *testing.T) {
+	t.Parallel()
+
+	a := &resource.State{
+		URN:      "urn:pulumi:dev::app::a:b:C::a",
+		Provider: "urn:pulumi:dev::app::pulumi:providers:aws::default::00000000-0000-0000-0000-000000000000",
+	}
+
+	report := ValidateSnapshot(&deploy.Snapshot{Resources: []*resource.State{a}})
+	assert.True(t, report.HasProblems())
+	assert.Len(t, report.OrphanedProviders, 1)
+}
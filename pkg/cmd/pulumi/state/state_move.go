@@ -0,0 +1,336 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/cmd/pulumi/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	pkgWorkspace "github.com/pulumi/pulumi/pkg/v3/workspace"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/env"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+func newStateMoveCommand(ws pkgWorkspace.Context, lm backend.LoginManager) *cobra.Command {
+	var stack string
+	var yes bool
+	var dryRun bool
+	var fromFile string
+	var regexPattern string
+	var replacePattern string
+
+	cmd := &cobra.Command{
+		Use:     "mv [old-urn] [new-urn]",
+		Aliases: []string{"rename"},
+		Short:   "Renames or moves resources within a stack's state by rewriting their URNs",
+		Long: `Renames or moves resources within a stack's state by rewriting their URNs
+
+This command rewrites the URN of one or more resources in a stack's state, along with every
+reference to those resources elsewhere in the state: parent/child relationships, dependencies,
+per-property dependencies, deleted-with relationships, and provider references.
+
+A single resource can be renamed by passing its old and new URN:
+
+	pulumi state mv 'urn:pulumi:dev::app::my:mod:Thing::old-name' 'urn:pulumi:dev::app::my:mod:Thing::new-name'
+
+Many resources can be renamed at once with ` + "`--from-file`" + `, which reads a JSON object mapping old
+URNs to new URNs:
+
+	pulumi state mv --from-file mapping.json
+
+Or with ` + "`--regex`" + ` and ` + "`--replace`" + `, which rewrites the URN of every resource matching the regex --
+useful for renaming a stack or project in bulk:
+
+	pulumi state mv --regex '^urn:pulumi:dev::app::(.*)$' --replace 'urn:pulumi:prod::app::$1'
+
+Pass ` + "`--dry-run`" + ` to see which resources would be renamed without modifying the stack's state.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+`,
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			sink := cmdutil.Diag()
+			yes = yes || env.SkipConfirmations.Value()
+			showPrompt := !yes
+
+			if err := validateMoveArgs(args, fromFile, regexPattern, replacePattern); err != nil {
+				return err
+			}
+
+			var abort *dryRunAbort
+			err := runTotalStateEdit(ctx, sink, ws, lm, stack, showPrompt,
+				func(opts display.Options, snap *deploy.Snapshot) error {
+					mapping, err := resolveURNMapping(snap, args, fromFile, regexPattern, replacePattern)
+					if err != nil {
+						return err
+					}
+					if len(mapping) == 0 {
+						return errors.New("no resources matched the requested rename")
+					}
+					if err := validateMapping(snap, mapping); err != nil {
+						return err
+					}
+					if dryRun {
+						return &dryRunAbort{mapping: mapping}
+					}
+
+					moveResources(snap, mapping)
+					return nil
+				})
+			if errors.As(err, &abort) {
+				printMoveReport(cmd, "Would rename", abort.mapping)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Resources renamed")
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the resources that would be renamed, without changing the stack's state")
+	cmd.Flags().StringVar(&fromFile, "from-file", "",
+		"Path to a JSON file mapping old URNs to new URNs, for renaming many resources at once")
+	cmd.Flags().StringVar(&regexPattern, "regex", "",
+		"A regular expression matched against every resource's URN; each match is rewritten using --replace")
+	cmd.Flags().StringVar(&replacePattern, "replace", "",
+		"The replacement pattern used with --regex; supports Go regexp replacement syntax (e.g. $1)")
+	return cmd
+}
+
+// dryRunAbort is returned by the runTotalStateEdit callback to signal that the requested
+// rewrite is well-formed and would be applied, without actually committing it -- runTotalStateEdit
+// treats any non-nil error as a reason not to persist the edited snapshot.
+type dryRunAbort struct {
+	mapping map[resource.URN]resource.URN
+}
+
+func (d *dryRunAbort) Error() string {
+	return "dry run: no changes were made"
+}
+
+// validateMoveArgs checks that exactly one way of specifying the rename was given, before we
+// go to the trouble of loading the stack's state.
+func validateMoveArgs(args []string, fromFile, regexPattern, replacePattern string) error {
+	sourcesSpecified := 0
+	if len(args) > 0 {
+		sourcesSpecified++
+	}
+	if fromFile != "" {
+		sourcesSpecified++
+	}
+	if regexPattern != "" || replacePattern != "" {
+		sourcesSpecified++
+	}
+
+	switch {
+	case sourcesSpecified == 0:
+		return errors.New("specify a rename: <old-urn> <new-urn>, --from-file, or --regex with --replace")
+	case sourcesSpecified > 1:
+		return errors.New("specify exactly one of <old-urn> <new-urn>, --from-file, or --regex/--replace")
+	case len(args) > 0 && len(args) != 2:
+		return errors.New("expected exactly two arguments: <old-urn> <new-urn>")
+	case regexPattern != "" && replacePattern == "":
+		return errors.New("--regex requires --replace")
+	case replacePattern != "" && regexPattern == "":
+		return errors.New("--replace requires --regex")
+	}
+	return nil
+}
+
+// resolveURNMapping builds the old-URN-to-new-URN mapping requested on the command line. snap
+// is only consulted for --regex, which needs the full set of URNs to match against.
+func resolveURNMapping(
+	snap *deploy.Snapshot, args []string, fromFile, regexPattern, replacePattern string,
+) (map[resource.URN]resource.URN, error) {
+	switch {
+	case len(args) == 2:
+		return map[resource.URN]resource.URN{resource.URN(args[0]): resource.URN(args[1])}, nil
+	case fromFile != "":
+		return mappingFromFile(fromFile)
+	case regexPattern != "":
+		return mappingFromRegex(snap, regexPattern, replacePattern)
+	default:
+		return nil, errors.New("no URN rewrites specified")
+	}
+}
+
+func mappingFromFile(path string) (map[resource.URN]resource.URN, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	mapping := make(map[resource.URN]resource.URN, len(raw))
+	for oldURN, newURN := range raw {
+		mapping[resource.URN(oldURN)] = resource.URN(newURN)
+	}
+	return mapping, nil
+}
+
+func mappingFromRegex(snap *deploy.Snapshot, pattern, replace string) (map[resource.URN]resource.URN, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --regex: %w", err)
+	}
+
+	mapping := make(map[resource.URN]resource.URN)
+	for _, res := range snap.Resources {
+		old := string(res.URN)
+		if !re.MatchString(old) {
+			continue
+		}
+		mapping[res.URN] = resource.URN(re.ReplaceAllString(old, replace))
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("--regex %q matched no resources", pattern)
+	}
+	return mapping, nil
+}
+
+// validateMapping refuses a rewrite that references a resource not present in the stack, or
+// that would leave two resources with the same URN.
+func validateMapping(snap *deploy.Snapshot, mapping map[resource.URN]resource.URN) error {
+	existing := make(map[resource.URN]bool, len(snap.Resources))
+	for _, res := range snap.Resources {
+		existing[res.URN] = true
+	}
+
+	var missing []string
+	for old := range mapping {
+		if !existing[old] {
+			missing = append(missing, string(old))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("no resource(s) found with URN(s):\n  %s", strings.Join(missing, "\n  "))
+	}
+
+	finalURNs := make(map[resource.URN][]resource.URN, len(snap.Resources))
+	for _, res := range snap.Resources {
+		finalURN := res.URN
+		if newURN, ok := mapping[finalURN]; ok {
+			finalURN = newURN
+		}
+		finalURNs[finalURN] = append(finalURNs[finalURN], res.URN)
+	}
+
+	var collisions []string
+	for finalURN, sources := range finalURNs {
+		if len(sources) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s would be produced by: %v", finalURN, sources))
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return fmt.Errorf("rewrite would introduce duplicate URNs:\n  %s", strings.Join(collisions, "\n  "))
+	}
+
+	return nil
+}
+
+// moveResources rewrites the URN of every resource named in mapping, along with every
+// reference to those URNs elsewhere in the snapshot. Callers must have already validated
+// mapping with validateMapping.
+func moveResources(snap *deploy.Snapshot, mapping map[resource.URN]resource.URN) {
+	for _, res := range snap.Resources {
+		if newURN, ok := mapping[res.URN]; ok {
+			res.URN = newURN
+		}
+		if newParent, ok := mapping[res.Parent]; ok {
+			res.Parent = newParent
+		}
+		if newDeletedWith, ok := mapping[res.DeletedWith]; ok {
+			res.DeletedWith = newDeletedWith
+		}
+
+		for i, dep := range res.Dependencies {
+			if newDep, ok := mapping[dep]; ok {
+				res.Dependencies[i] = newDep
+			}
+		}
+
+		for key, deps := range res.PropertyDependencies {
+			for i, dep := range deps {
+				if newDep, ok := mapping[dep]; ok {
+					deps[i] = newDep
+				}
+			}
+			res.PropertyDependencies[key] = deps
+		}
+
+		if newProvider, ok := rewriteProviderReference(res.Provider, mapping); ok {
+			res.Provider = newProvider
+		}
+	}
+}
+
+// rewriteProviderReference rewrites the URN embedded in a "provider reference" (as stored in
+// resource.State.Provider) if it's in mapping, returning ok=false if ref is empty, malformed,
+// or doesn't need rewriting.
+func rewriteProviderReference(ref string, mapping map[resource.URN]resource.URN) (string, bool) {
+	if ref == "" {
+		return "", false
+	}
+
+	urn, id, err := providers.ParseReference(ref)
+	if err != nil {
+		return "", false
+	}
+
+	newURN, ok := mapping[urn]
+	if !ok {
+		return "", false
+	}
+	return providers.NewReference(newURN, id).String(), true
+}
+
+func printMoveReport(cmd *cobra.Command, verb string, mapping map[resource.URN]resource.URN) {
+	olds := make([]resource.URN, 0, len(mapping))
+	for old := range mapping {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool { return olds[i] < olds[j] })
+
+	cmd.Printf("%s %d resource(s):\n", verb, len(olds))
+	for _, old := range olds {
+		cmd.Printf("  - %s\n  + %s\n", old, mapping[old])
+	}
+}
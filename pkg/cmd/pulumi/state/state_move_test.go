@@ -0,0 +1,117 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixtureSnapshot() *deploy.Snapshot {
+	parent := &resource.State{URN: "urn:pulumi:dev::app::pkg:index:Parent::parent"}
+	child := &resource.State{
+		URN:    "urn:pulumi:dev::app::pkg:index:Child::child",
+		Parent: parent.URN,
+		Dependencies: []resource.URN{
+			parent.URN,
+		},
+		PropertyDependencies: map[resource.PropertyKey][]resource.URN{
+			"input": {parent.URN},
+		},
+	}
+	return &deploy.Snapshot{Resources: []*resource.State{parent, child}}
+}
+
+func TestMoveResourcesRewritesReferences(t *testing.T) {
+	t.Parallel()
+
+	snap := fixtureSnapshot()
+	newParentURN := resource.URN("urn:pulumi:prod::app::pkg:index:Parent::parent")
+	mapping := map[resource.URN]resource.URN{
+		snap.Resources[0].URN: newParentURN,
+	}
+
+	moveResources(snap, mapping)
+
+	assert.Equal(t, newParentURN, snap.Resources[0].URN)
+	assert.Equal(t, newParentURN, snap.Resources[1].Parent)
+	assert.Equal(t, []resource.URN{newParentURN}, snap.Resources[1].Dependencies)
+	assert.Equal(t, []resource.URN{newParentURN}, snap.Resources[1].PropertyDependencies["input"])
+}
+
+func TestValidateMappingRejectsMissingResource(t *testing.T) {
+	t.Parallel()
+
+	snap := fixtureSnapshot()
+	mapping := map[resource.URN]resource.URN{
+		"urn:pulumi:dev::app::pkg:index:Missing::missing": "urn:pulumi:dev::app::pkg:index:Missing::renamed",
+	}
+
+	err := validateMapping(snap, mapping)
+	assert.ErrorContains(t, err, "no resource(s) found")
+}
+
+func TestValidateMappingRejectsCollisions(t *testing.T) {
+	t.Parallel()
+
+	snap := fixtureSnapshot()
+	mapping := map[resource.URN]resource.URN{
+		snap.Resources[0].URN: snap.Resources[1].URN,
+	}
+
+	err := validateMapping(snap, mapping)
+	assert.ErrorContains(t, err, "duplicate URNs")
+}
+
+func TestMappingFromRegex(t *testing.T) {
+	t.Parallel()
+
+	snap := fixtureSnapshot()
+	mapping, err := mappingFromRegex(snap, `^urn:pulumi:dev::(.*)$`, "urn:pulumi:prod::$1")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		resource.URN("urn:pulumi:prod::app::pkg:index:Parent::parent"),
+		mapping[snap.Resources[0].URN],
+	)
+	assert.Equal(t,
+		resource.URN("urn:pulumi:prod::app::pkg:index:Child::child"),
+		mapping[snap.Resources[1].URN],
+	)
+}
+
+func TestMappingFromRegexNoMatches(t *testing.T) {
+	t.Parallel()
+
+	snap := fixtureSnapshot()
+	_, err := mappingFromRegex(snap, `^no-such-prefix::(.*)$`, "x::$1")
+	assert.Error(t, err)
+}
+
+func TestValidateMoveArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validateMoveArgs([]string{"a", "b"}, "", "", ""))
+	assert.NoError(t, validateMoveArgs(nil, "mapping.json", "", ""))
+	assert.NoError(t, validateMoveArgs(nil, "", "^a$", "b"))
+
+	assert.Error(t, validateMoveArgs(nil, "", "", ""))
+	assert.Error(t, validateMoveArgs([]string{"a", "b"}, "mapping.json", "", ""))
+	assert.Error(t, validateMoveArgs(nil, "", "^a$", ""))
+}
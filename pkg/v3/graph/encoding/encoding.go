@@ -0,0 +1,142 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encoding provides pluggable serializations of a graph.Graph, for use by commands
+// that export a stack's dependency graph in something other than Graphviz's DOT format.
+package encoding
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+)
+
+// Format identifies a supported graph serialization.
+type Format string
+
+const (
+	// DOT is Graphviz's native format. It is not handled by this package -- callers continue
+	// to use the dotconv package directly, since it predates this package and already supports
+	// fragment injection -- but it is part of the Format enum so callers can validate
+	// `--format` against a single list.
+	DOT Format = "dot"
+	// JSON emits a stable, versionless schema describing nodes and edges, intended for
+	// consumption by downstream tooling.
+	JSON Format = "json"
+	// Mermaid emits a `graph TD` block suitable for embedding directly in Markdown.
+	Mermaid Format = "mermaid"
+	// GraphML emits XML consumable by tools such as Gephi or yEd.
+	GraphML Format = "graphml"
+	// SVG renders the graph as a vector image, shelling out to Graphviz's `dot` when it's
+	// available on PATH and falling back to a simple built-in layout otherwise.
+	SVG Format = "svg"
+)
+
+// ParseFormat validates and normalizes a user-supplied --format value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case DOT, JSON, Mermaid, GraphML, SVG:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q (expected one of dot, json, mermaid, graphml, svg)", s)
+	}
+}
+
+// Kinded is implemented by edges that know whether they represent a dependency or a
+// parent/child relationship. Encoders that distinguish edge kinds (JSON, GraphML) use this to
+// tag each edge; edges that don't implement it are treated as dependency edges.
+type Kinded interface {
+	Kind() string
+}
+
+const (
+	// KindDependency tags an edge introduced by a resource dependency.
+	KindDependency = "dependency"
+	// KindParent tags an edge introduced by a resource parent/child relationship.
+	KindParent = "parent"
+)
+
+// Encoder serializes a graph.Graph to a writer in a specific format.
+type Encoder interface {
+	Encode(g graph.Graph, w io.Writer) error
+}
+
+// encoders maps each supported format other than DOT to the Encoder that implements it.
+var encoders = map[Format]Encoder{
+	JSON:    &jsonEncoder{},
+	Mermaid: &mermaidEncoder{},
+	GraphML: &graphmlEncoder{},
+	SVG:     &svgEncoder{},
+}
+
+// Encode writes g to w using the named format. DOT is not handled here; callers should
+// continue to invoke dotconv.Print directly for that format.
+func Encode(format Format, g graph.Graph, w io.Writer) error {
+	enc, ok := encoders[format]
+	if !ok {
+		return fmt.Errorf("no encoder registered for format %q", format)
+	}
+	return enc.Encode(g, w)
+}
+
+// walk collects every vertex and edge reachable from g's roots, via a depth-first traversal
+// over both outgoing and incoming edges. Vertices and edges reachable via more than one path
+// are only visited, and returned, once. The returned order follows the order of g.Roots() and
+// of each vertex's edges, so it is only deterministic when those orders are.
+func walk(g graph.Graph) ([]graph.Vertex, []graph.Edge) {
+	var vertices []graph.Vertex
+	var edges []graph.Edge
+	seenVertex := make(map[graph.Vertex]bool)
+	seenEdge := make(map[graph.Edge]bool)
+
+	var visit func(v graph.Vertex)
+	visit = func(v graph.Vertex) {
+		if v == nil || seenVertex[v] {
+			return
+		}
+		seenVertex[v] = true
+		vertices = append(vertices, v)
+
+		for _, e := range v.Outs() {
+			if !seenEdge[e] {
+				seenEdge[e] = true
+				edges = append(edges, e)
+			}
+			visit(e.To())
+		}
+		for _, e := range v.Ins() {
+			if !seenEdge[e] {
+				seenEdge[e] = true
+				edges = append(edges, e)
+			}
+			visit(e.From())
+		}
+	}
+
+	for _, root := range g.Roots() {
+		visit(root.To())
+	}
+
+	return vertices, edges
+}
+
+// edgeKind returns the edge's kind, defaulting to KindDependency when the edge doesn't
+// implement Kinded.
+func edgeKind(e graph.Edge) string {
+	if k, ok := e.(Kinded); ok {
+		return k.Kind()
+	}
+	return KindDependency
+}
@@ -0,0 +1,158 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureEdge and fixtureVertex implement graph.Edge and graph.Vertex directly, rather than
+// pulling in the stack command's unexported dependencyGraph, so this package can be tested in
+// isolation.
+type fixtureEdge struct {
+	from, to *fixtureVertex
+	label    string
+	kind     string
+}
+
+func (e *fixtureEdge) Data() interface{} { return nil }
+func (e *fixtureEdge) Label() string     { return e.label }
+func (e *fixtureEdge) Kind() string      { return e.kind }
+
+func (e *fixtureEdge) To() graph.Vertex { return e.to }
+
+func (e *fixtureEdge) From() graph.Vertex {
+	if e.from == nil {
+		return nil
+	}
+	return e.from
+}
+
+type fixtureVertex struct {
+	state *resource.State
+	ins   []graph.Edge
+	outs  []graph.Edge
+}
+
+func (v *fixtureVertex) Data() interface{}  { return v.state }
+func (v *fixtureVertex) Label() string      { return string(v.state.URN) }
+func (v *fixtureVertex) Ins() []graph.Edge  { return v.ins }
+func (v *fixtureVertex) Outs() []graph.Edge { return v.outs }
+
+type fixtureGraph struct {
+	vertices []*fixtureVertex
+}
+
+func (g *fixtureGraph) Roots() []graph.Edge {
+	edges := make([]graph.Edge, len(g.vertices))
+	for i, v := range g.vertices {
+		edges[i] = &fixtureEdge{to: v}
+	}
+	return edges
+}
+
+// newFixture builds a two-resource graph: b depends on a, and is parented to a.
+func newFixture() *fixtureGraph {
+	a := &fixtureVertex{state: &resource.State{
+		URN:  "urn:pulumi:dev::app::a:b:C::a",
+		Type: "a:b:C",
+	}}
+	b := &fixtureVertex{state: &resource.State{
+		URN:     "urn:pulumi:dev::app::a:b:C::b",
+		Type:    "a:b:C",
+		Parent:  a.state.URN,
+		Protect: true,
+	}}
+
+	dep := &fixtureEdge{from: a, to: b, label: "input", kind: KindDependency}
+	b.ins = append(b.ins, dep)
+	a.outs = append(a.outs, dep)
+
+	par := &fixtureEdge{from: b, to: a, kind: KindParent}
+	b.outs = append(b.outs, par)
+	a.ins = append(a.ins, par)
+
+	return &fixtureGraph{vertices: []*fixtureVertex{a, b}}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, (&jsonEncoder{}).Encode(newFixture(), &buf))
+
+	out := buf.String()
+	assert.Contains(t, out, `"kind": "dependency"`)
+	assert.Contains(t, out, `"kind": "parent"`)
+	assert.Contains(t, out, `"protect": true`)
+	assert.Contains(t, out, `"urn:pulumi:dev::app::a:b:C::a"`)
+}
+
+func TestMermaidEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, (&mermaidEncoder{}).Encode(newFixture(), &buf))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "graph TD\n"))
+	assert.Contains(t, out, "-->")
+	assert.Contains(t, out, "-- input -->")
+}
+
+func TestGraphMLEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, (&graphmlEncoder{}).Encode(newFixture(), &buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "<graphml")
+	assert.Contains(t, out, `edgedefault="directed"`)
+	assert.Contains(t, out, `key="kind"`)
+}
+
+func TestSVGFallbackEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, renderSVGFallback(newFixture(), &buf))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<svg "))
+	assert.True(t, strings.HasSuffix(out, "</svg>\n"))
+	assert.Contains(t, out, "urn:pulumi:dev::app::a:b:C::a")
+	assert.Equal(t, 2, strings.Count(out, "<line "), "expected one <line> per edge (dependency + parent)")
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range []string{"dot", "json", "mermaid", "graphml", "svg"} {
+		got, err := ParseFormat(f)
+		assert.NoError(t, err)
+		assert.Equal(t, Format(f), got)
+	}
+
+	_, err := ParseFormat("yaml")
+	assert.Error(t, err)
+}
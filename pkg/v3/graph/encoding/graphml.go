@@ -0,0 +1,123 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+)
+
+// graphmlEncoder emits GraphML, consumable by tools such as Gephi or yEd.
+type graphmlEncoder struct{}
+
+const (
+	keyNodeType     = "type"
+	keyNodeParent   = "parent"
+	keyNodeProvider = "provider"
+	keyNodeProtect  = "protect"
+	keyEdgeKind     = "kind"
+)
+
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (*graphmlEncoder) Encode(g graph.Graph, w io.Writer) error {
+	vertices, edges := walk(g)
+
+	doc := graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{ID: keyNodeType, For: "node", Name: "type", Type: "string"},
+			{ID: keyNodeParent, For: "node", Name: "parent", Type: "string"},
+			{ID: keyNodeProvider, For: "node", Name: "provider", Type: "string"},
+			{ID: keyNodeProtect, For: "node", Name: "protect", Type: "boolean"},
+			{ID: keyEdgeKind, For: "edge", Name: "kind", Type: "string"},
+		},
+		Graph: graphmlGraph{ID: "stack", EdgeDefault: "directed"},
+	}
+
+	for _, v := range vertices {
+		info := describeVertex(v)
+		node := graphmlNode{
+			ID: info.urn,
+			Data: []graphmlData{
+				{Key: keyNodeType, Value: info.typ},
+				{Key: keyNodeParent, Value: info.parent},
+				{Key: keyNodeProvider, Value: info.provider},
+			},
+		}
+		if info.protect {
+			node.Data = append(node.Data, graphmlData{Key: keyNodeProtect, Value: "true"})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+
+	for _, e := range edges {
+		from, to := e.From(), e.To()
+		if from == nil || to == nil {
+			continue
+		}
+
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			Source: describeVertex(from).urn,
+			Target: describeVertex(to).urn,
+			Data:   []graphmlData{{Key: keyEdgeKind, Value: edgeKind(e)}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,99 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+)
+
+// Document is the stable schema produced by the JSON encoder. It is intended for consumption
+// by downstream tooling, so changes to it should stay backwards compatible -- add new,
+// optional fields rather than renaming or removing existing ones.
+type Document struct {
+	Nodes []JSONNode `json:"nodes"`
+	Edges []JSONEdge `json:"edges"`
+}
+
+// JSONNode describes a single resource in the graph.
+type JSONNode struct {
+	URN      string `json:"urn"`
+	Type     string `json:"type,omitempty"`
+	Parent   string `json:"parent,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Protect  bool   `json:"protect,omitempty"`
+}
+
+// JSONEdge describes a directed edge between two resources.
+type JSONEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Kind is either "dependency" or "parent".
+	Kind string `json:"kind"`
+	// Properties lists the input properties responsible for a dependency edge, when known.
+	Properties []string `json:"properties,omitempty"`
+}
+
+type jsonEncoder struct{}
+
+func (*jsonEncoder) Encode(g graph.Graph, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(buildDocument(g))
+}
+
+func buildDocument(g graph.Graph) Document {
+	vertices, edges := walk(g)
+
+	doc := Document{
+		Nodes: make([]JSONNode, 0, len(vertices)),
+		Edges: make([]JSONEdge, 0, len(edges)),
+	}
+
+	for _, v := range vertices {
+		info := describeVertex(v)
+		doc.Nodes = append(doc.Nodes, JSONNode{
+			URN:      info.urn,
+			Type:     info.typ,
+			Parent:   info.parent,
+			Provider: info.provider,
+			Protect:  info.protect,
+		})
+	}
+
+	for _, e := range edges {
+		from, to := e.From(), e.To()
+		if from == nil || to == nil {
+			continue
+		}
+
+		var props []string
+		if label := e.Label(); label != "" {
+			props = strings.Split(label, ", ")
+		}
+
+		doc.Edges = append(doc.Edges, JSONEdge{
+			From:       describeVertex(from).urn,
+			To:         describeVertex(to).urn,
+			Kind:       edgeKind(e),
+			Properties: props,
+		})
+	}
+
+	return doc
+}
@@ -0,0 +1,62 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+)
+
+// mermaidEncoder emits a `graph TD` block suitable for embedding directly in a Markdown
+// document, e.g. inside a ```mermaid fenced code block.
+type mermaidEncoder struct{}
+
+func (*mermaidEncoder) Encode(g graph.Graph, w io.Writer) error {
+	vertices, edges := walk(g)
+
+	if _, err := fmt.Fprintln(w, "graph TD"); err != nil {
+		return err
+	}
+
+	ids := make(map[graph.Vertex]string, len(vertices))
+	for i, v := range vertices {
+		id := fmt.Sprintf("n%d", i)
+		ids[v] = id
+		if _, err := fmt.Fprintf(w, "    %s[%q]\n", id, describeVertex(v).urn); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range edges {
+		from, to := e.From(), e.To()
+		if from == nil || to == nil {
+			continue
+		}
+
+		arrow := "-->"
+		if label := e.Label(); label != "" {
+			arrow = fmt.Sprintf("-- %s -->", strings.ReplaceAll(label, `"`, "'"))
+		}
+
+		if _, err := fmt.Fprintf(w, "    %s %s %s\n", ids[from], arrow, ids[to]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// nodeInfo captures the resource-shaped fields we know how to render for a vertex. It is
+// populated from the vertex's Data() when that data is a *resource.State; graphs built over
+// other kinds of data still encode, just with only urn (taken from Label()) set.
+type nodeInfo struct {
+	urn      string
+	typ      string
+	parent   string
+	provider string
+	protect  bool
+}
+
+func describeVertex(v graph.Vertex) nodeInfo {
+	info := nodeInfo{urn: v.Label()}
+
+	state, ok := v.Data().(*resource.State)
+	if !ok {
+		return info
+	}
+
+	info.urn = string(state.URN)
+	info.typ = string(state.Type)
+	if state.Parent != "" {
+		info.parent = string(state.Parent)
+	}
+	if state.Provider != "" {
+		info.provider = state.Provider
+	}
+	info.protect = state.Protect
+	return info
+}
@@ -0,0 +1,111 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/pulumi/pulumi/pkg/v3/graph"
+	"github.com/pulumi/pulumi/pkg/v3/graph/dotconv"
+)
+
+// svgEncoder renders a graph as an SVG image. When the Graphviz `dot` binary is available on
+// PATH, it's used to lay out and render the graph, since it produces far more readable output
+// than anything we could cheaply do ourselves. Otherwise we fall back to a simple top-to-bottom
+// layout that at least produces something usable.
+type svgEncoder struct{}
+
+func (*svgEncoder) Encode(g graph.Graph, w io.Writer) error {
+	path, err := exec.LookPath("dot")
+	if err != nil {
+		return renderSVGFallback(g, w)
+	}
+
+	var dot bytes.Buffer
+	if err := dotconv.Print(g, &dot, ""); err != nil {
+		return fmt.Errorf("rendering intermediate DOT representation: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, "-Tsvg")
+	cmd.Stdin = &dot
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running `dot -Tsvg`: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// renderSVGFallback draws one row per vertex, in discovery order, with straight lines
+// connecting dependency and parent edges. It exists so `--format svg` still produces usable
+// output when Graphviz isn't installed; install Graphviz for a readable, laid-out graph.
+func renderSVGFallback(g graph.Graph, w io.Writer) error {
+	const (
+		rowHeight = 40
+		boxWidth  = 320
+		boxHeight = 24
+		margin    = 20
+	)
+
+	vertices, edges := walk(g)
+
+	width := boxWidth + 2*margin
+	height := len(vertices)*rowHeight + margin
+
+	y := make(map[graph.Vertex]int, len(vertices))
+
+	var body bytes.Buffer
+	for i, v := range vertices {
+		cy := margin + i*rowHeight
+		y[v] = cy + boxHeight/2
+		fmt.Fprintf(&body,
+			"<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"#f5f5f5\" stroke=\"#333\"/>\n",
+			margin, cy, boxWidth, boxHeight)
+		fmt.Fprintf(&body,
+			"<text x=\"%d\" y=\"%d\" font-size=\"10\" font-family=\"monospace\">%s</text>\n",
+			margin+4, cy+boxHeight/2+4, escapeSVGText(describeVertex(v).urn))
+	}
+
+	for _, e := range edges {
+		from, to := e.From(), e.To()
+		if from == nil || to == nil {
+			continue
+		}
+		fmt.Fprintf(&body,
+			"<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#999\"/>\n",
+			margin+boxWidth, y[from], margin+boxWidth, y[to])
+	}
+
+	if _, err := fmt.Fprintf(w,
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", width, height); err != nil {
+		return err
+	}
+	if _, err := body.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</svg>\n")
+	return err
+}
+
+func escapeSVGText(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
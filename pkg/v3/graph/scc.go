@@ -0,0 +1,134 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+// SCC is a single strongly connected component: a maximal set of vertices where every vertex
+// can reach every other vertex by following directed (outgoing) edges.
+type SCC []Vertex
+
+// HasCycle reports whether c represents an actual cycle. A strongly connected component of
+// more than one vertex is always a cycle; a component of exactly one vertex is a cycle only if
+// that vertex has an edge back to itself.
+func (c SCC) HasCycle() bool {
+	if len(c) > 1 {
+		return true
+	}
+	if len(c) == 1 {
+		for _, e := range c[0].Outs() {
+			if e.To() == c[0] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StronglyConnectedComponents computes the strongly connected components of every vertex
+// reachable from g's roots, using Tarjan's algorithm and considering only outgoing edges.
+// Components are returned in reverse topological order, as Tarjan's algorithm naturally
+// produces them.
+func StronglyConnectedComponents(g Graph) []SCC {
+	t := &tarjan{
+		index:   make(map[Vertex]int),
+		lowlink: make(map[Vertex]int),
+		onStack: make(map[Vertex]bool),
+	}
+
+	for _, v := range reachableVertices(g) {
+		if _, visited := t.index[v]; !visited {
+			t.strongconnect(v)
+		}
+	}
+
+	return t.components
+}
+
+type tarjan struct {
+	counter    int
+	index      map[Vertex]int
+	lowlink    map[Vertex]int
+	onStack    map[Vertex]bool
+	stack      []Vertex
+	components []SCC
+}
+
+func (t *tarjan) strongconnect(v Vertex) {
+	t.index[v] = t.counter
+	t.lowlink[v] = t.counter
+	t.counter++
+	t.stack = append(t.stack, v)
+	t.onStack[v] = true
+
+	for _, e := range v.Outs() {
+		w := e.To()
+		if w == nil {
+			continue
+		}
+
+		if _, visited := t.index[w]; !visited {
+			t.strongconnect(w)
+			if t.lowlink[w] < t.lowlink[v] {
+				t.lowlink[v] = t.lowlink[w]
+			}
+		} else if t.onStack[w] {
+			if t.index[w] < t.lowlink[v] {
+				t.lowlink[v] = t.index[w]
+			}
+		}
+	}
+
+	if t.lowlink[v] == t.index[v] {
+		var component SCC
+		for {
+			n := len(t.stack) - 1
+			w := t.stack[n]
+			t.stack = t.stack[:n]
+			t.onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		t.components = append(t.components, component)
+	}
+}
+
+// reachableVertices collects every vertex reachable from g's roots, following edges in either
+// direction so that a root set that only names one side of the graph still reaches all of it.
+func reachableVertices(g Graph) []Vertex {
+	var result []Vertex
+	seen := make(map[Vertex]bool)
+
+	var visit func(v Vertex)
+	visit = func(v Vertex) {
+		if v == nil || seen[v] {
+			return
+		}
+		seen[v] = true
+		result = append(result, v)
+		for _, e := range v.Outs() {
+			visit(e.To())
+		}
+		for _, e := range v.Ins() {
+			visit(e.From())
+		}
+	}
+
+	for _, root := range g.Roots() {
+		visit(root.To())
+	}
+
+	return result
+}
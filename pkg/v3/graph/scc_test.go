@@ -0,0 +1,102 @@
+// Copyright 2016-2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixtureEdge struct {
+	from, to *fixtureVertex
+}
+
+func (e *fixtureEdge) Data() interface{} { return nil }
+func (e *fixtureEdge) Label() string     { return "" }
+func (e *fixtureEdge) To() Vertex        { return e.to }
+func (e *fixtureEdge) From() Vertex      { return e.from }
+
+type fixtureVertex struct {
+	name string
+	ins  []Edge
+	outs []Edge
+}
+
+func (v *fixtureVertex) Data() interface{} { return v.name }
+func (v *fixtureVertex) Label() string     { return v.name }
+func (v *fixtureVertex) Ins() []Edge       { return v.ins }
+func (v *fixtureVertex) Outs() []Edge      { return v.outs }
+
+type fixtureGraph struct {
+	vertices []*fixtureVertex
+}
+
+func (g *fixtureGraph) Roots() []Edge {
+	edges := make([]Edge, len(g.vertices))
+	for i, v := range g.vertices {
+		edges[i] = &fixtureEdge{to: v}
+	}
+	return edges
+}
+
+func link(from, to *fixtureVertex) {
+	e := &fixtureEdge{from: from, to: to}
+	from.outs = append(from.outs, e)
+	to.ins = append(to.ins, e)
+}
+
+func TestStronglyConnectedComponentsAcyclic(t *testing.T) {
+	t.Parallel()
+
+	a := &fixtureVertex{name: "a"}
+	b := &fixtureVertex{name: "b"}
+	c := &fixtureVertex{name: "c"}
+	link(a, b)
+	link(b, c)
+
+	sccs := StronglyConnectedComponents(&fixtureGraph{vertices: []*fixtureVertex{a, b, c}})
+	assert.Len(t, sccs, 3)
+	for _, scc := range sccs {
+		assert.False(t, scc.HasCycle())
+	}
+}
+
+func TestStronglyConnectedComponentsCycle(t *testing.T) {
+	t.Parallel()
+
+	a := &fixtureVertex{name: "a"}
+	b := &fixtureVertex{name: "b"}
+	c := &fixtureVertex{name: "c"}
+	link(a, b)
+	link(b, c)
+	link(c, a)
+
+	sccs := StronglyConnectedComponents(&fixtureGraph{vertices: []*fixtureVertex{a, b, c}})
+	assert.Len(t, sccs, 1)
+	assert.True(t, sccs[0].HasCycle())
+	assert.Len(t, sccs[0], 3)
+}
+
+func TestStronglyConnectedComponentsSelfLoop(t *testing.T) {
+	t.Parallel()
+
+	a := &fixtureVertex{name: "a"}
+	link(a, a)
+
+	sccs := StronglyConnectedComponents(&fixtureGraph{vertices: []*fixtureVertex{a}})
+	assert.Len(t, sccs, 1)
+	assert.True(t, sccs[0].HasCycle())
+}